@@ -0,0 +1,242 @@
+package main
+
+import (
+	"crypto/sha1"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/anacrolix/torrent/bencode"
+	"github.com/anacrolix/torrent/metainfo"
+	wailsruntime "github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+const (
+	minPieceLength   = 16 * 1024
+	maxPieceLength   = 16 * 1024 * 1024
+	targetPieceCount = 1500
+)
+
+// CreateTorrentOptions configures CreateTorrentFromFiles.
+type CreateTorrentOptions struct {
+	PieceLength int64      `json:"pieceLength"` // 0 auto-selects based on total size
+	Private     bool       `json:"private"`
+	Trackers    [][]string `json:"trackers"`
+	WebSeeds    []string   `json:"webSeeds"`
+	Comment     string     `json:"comment"`
+	CreatedBy   string     `json:"createdBy"`
+	Source      string     `json:"source"`
+}
+
+// choosePieceLength targets roughly targetPieceCount pieces, clamped to a
+// power-of-two between minPieceLength and maxPieceLength.
+func choosePieceLength(totalSize int64) int64 {
+	raw := totalSize / targetPieceCount
+	if raw < minPieceLength {
+		return minPieceLength
+	}
+
+	length := int64(minPieceLength)
+	for length < raw && length < maxPieceLength {
+		length *= 2
+	}
+	return length
+}
+
+// buildMetaInfo hashes the given files into a real, verifiable torrent,
+// emitting "create-progress" events as pieces are hashed since this can
+// take minutes for large file sets.
+func (a *App) buildMetaInfo(paths []string, opts CreateTorrentOptions) (*metainfo.MetaInfo, error) {
+	type fileEntry struct {
+		path string
+		size int64
+	}
+
+	entries := make([]fileEntry, 0, len(paths))
+	var total int64
+	for _, p := range paths {
+		fi, err := os.Stat(p)
+		if err != nil {
+			return nil, fmt.Errorf("failed to stat file %s: %w", p, err)
+		}
+		entries = append(entries, fileEntry{path: p, size: fi.Size()})
+		total += fi.Size()
+	}
+
+	pieceLength := opts.PieceLength
+	if pieceLength <= 0 {
+		pieceLength = choosePieceLength(total)
+	}
+
+	info := metainfo.Info{
+		Name:        filepath.Base(paths[0]),
+		PieceLength: pieceLength,
+		Source:      opts.Source,
+	}
+	if opts.Private {
+		private := true
+		info.Private = &private
+	}
+
+	readers := make([]io.Reader, 0, len(entries))
+	for _, e := range entries {
+		f, err := os.Open(e.path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open file %s: %w", e.path, err)
+		}
+		defer f.Close()
+
+		readers = append(readers, f)
+		if len(entries) > 1 {
+			info.Files = append(info.Files, metainfo.FileInfo{
+				Path:   []string{filepath.Base(e.path)},
+				Length: e.size,
+			})
+		}
+	}
+	// A single file is encoded as a flat (BEP3) torrent: Length directly on
+	// the info dict, no Files list and no wrapping directory.
+	if len(entries) == 1 {
+		info.Length = entries[0].size
+	}
+
+	pieces, err := hashPieces(io.MultiReader(readers...), pieceLength, total, func(hashed int64) {
+		wailsruntime.EventsEmit(a.ctx, "create-progress", map[string]interface{}{
+			"bytesHashed": hashed,
+			"totalBytes":  total,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	info.Pieces = pieces
+
+	infoBytes, err := bencode.Marshal(info)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode info dict: %w", err)
+	}
+
+	createdBy := opts.CreatedBy
+	if createdBy == "" {
+		createdBy = "Btorrent"
+	}
+
+	mi := &metainfo.MetaInfo{
+		InfoBytes:    infoBytes,
+		AnnounceList: opts.Trackers,
+		Comment:      opts.Comment,
+		CreatedBy:    createdBy,
+		CreationDate: time.Now().Unix(),
+		UrlList:      opts.WebSeeds,
+	}
+	if len(opts.Trackers) > 0 && len(opts.Trackers[0]) > 0 {
+		mi.Announce = opts.Trackers[0][0]
+	}
+
+	return mi, nil
+}
+
+// hashPieces reads r in pieceLength-sized chunks, sha1-hashing each one into
+// the concatenated Pieces blob the torrent spec expects.
+func hashPieces(r io.Reader, pieceLength, total int64, onProgress func(hashed int64)) ([]byte, error) {
+	var pieces []byte
+	buf := make([]byte, pieceLength)
+	var hashed int64
+
+	for {
+		n, err := io.ReadFull(r, buf)
+		if n > 0 {
+			sum := sha1.Sum(buf[:n])
+			pieces = append(pieces, sum[:]...)
+			hashed += int64(n)
+			if onProgress != nil {
+				onProgress(hashed)
+			}
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to hash pieces: %w", err)
+		}
+	}
+
+	return pieces, nil
+}
+
+// CreateTorrentFromFiles hashes the selected files into a real .torrent,
+// writes it to a user-chosen path, and immediately starts seeding it.
+func (a *App) CreateTorrentFromFiles(files []string, opts CreateTorrentOptions) (string, error) {
+	if a.client == nil {
+		return "", fmt.Errorf("torrent client not initialized")
+	}
+
+	if len(files) == 0 {
+		return "", fmt.Errorf("no files provided")
+	}
+
+	savePath, err := wailsruntime.SaveFileDialog(a.ctx, wailsruntime.SaveDialogOptions{
+		Title:           "Save Torrent File",
+		DefaultFilename: filepath.Base(files[0]) + ".torrent",
+		Filters: []wailsruntime.FileFilter{
+			{DisplayName: "Torrent Files (*.torrent)", Pattern: "*.torrent"},
+		},
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to open save dialog: %w", err)
+	}
+	if savePath == "" {
+		return "", fmt.Errorf("save cancelled")
+	}
+
+	log.Printf("Hashing %d file(s) for new torrent...", len(files))
+	mi, err := a.buildMetaInfo(files, opts)
+	if err != nil {
+		return "", fmt.Errorf("failed to create torrent: %w", err)
+	}
+
+	out, err := os.Create(savePath)
+	if err != nil {
+		return "", fmt.Errorf("failed to create torrent file %s: %w", savePath, err)
+	}
+	if err := mi.Write(out); err != nil {
+		out.Close()
+		return "", fmt.Errorf("failed to write torrent file: %w", err)
+	}
+	out.Close()
+
+	t, err := a.client.AddTorrent(mi)
+	if err != nil {
+		return "", fmt.Errorf("failed to seed new torrent: %w", err)
+	}
+
+	hash := t.InfoHash().String()
+	a.setTorrentStorageMode(hash, a.defaultStorageMode)
+
+	a.speedsMutex.Lock()
+	a.downloadSpeeds[hash] = &speedTracker{lastTime: time.Now()}
+	a.uploadSpeeds[hash] = &speedTracker{lastTime: time.Now()}
+	a.speedsMutex.Unlock()
+
+	a.torrentsMutex.Lock()
+	a.torrents[hash] = t
+	a.torrentsMutex.Unlock()
+
+	t.Seeding()
+
+	a.saveResumeEntry(resumeEntry{
+		InfoHash:    hash,
+		Source:      "torrentfile",
+		TorrentPath: savePath,
+		Storage:     string(a.defaultStorageMode),
+		AddedAt:     time.Now(),
+	})
+
+	log.Printf("✓ Created and seeding torrent: %s (%s)", t.Name(), savePath)
+	wailsruntime.EventsEmit(a.ctx, "torrent-added", hash)
+
+	return savePath, nil
+}
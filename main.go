@@ -5,6 +5,7 @@ import (
 	"embed"
 	"encoding/json"
 	"fmt"
+	"io"
 	"log"
 	"os"
 	"os/exec"
@@ -20,6 +21,7 @@ import (
 	"github.com/wailsapp/wails/v2/pkg/options"
 	"github.com/wailsapp/wails/v2/pkg/options/assetserver"
 	wailsruntime "github.com/wailsapp/wails/v2/pkg/runtime"
+	"golang.org/x/time/rate"
 )
 
 //go:embed all:frontend/dist
@@ -43,6 +45,9 @@ type TorrentInfo struct {
 	ETA           string     `json:"eta"`
 	Files         []FileInfo `json:"files"`
 	AddedAt       time.Time  `json:"addedAt"`
+	WebSeeds      []string   `json:"webSeeds"`
+	Storage       string     `json:"storage"`
+	Throttled     bool       `json:"throttled"`
 }
 
 // FileInfo represents file information within a torrent
@@ -52,14 +57,17 @@ type FileInfo struct {
 	SizeStr  string  `json:"sizeStr"`
 	Progress float64 `json:"progress"`
 	Path     string  `json:"path"`
+	Priority string  `json:"priority"`
 }
 
 // Stats represents global statistics
 type Stats struct {
-	TotalDownloadSpeed string `json:"totalDownload"`
-	TotalUploadSpeed   string `json:"totalUpload"`
-	ActiveTorrents     int    `json:"activeTorrents"`
-	TotalPeers         int    `json:"totalPeers"`
+	TotalDownloadSpeed string     `json:"totalDownload"`
+	TotalUploadSpeed   string     `json:"totalUpload"`
+	ActiveTorrents     int        `json:"activeTorrents"`
+	TotalPeers         int        `json:"totalPeers"`
+	WebSeedBytes       int64      `json:"webSeedBytes"`
+	RateLimits         RateLimits `json:"rateLimits"`
 }
 
 // speedTracker tracks download/upload speeds
@@ -75,19 +83,57 @@ type App struct {
 	client         *torrent.Client
 	torrents       map[string]*torrent.Torrent
 	torrentsMutex  sync.RWMutex
+	pausedTorrents map[string]bool // infoHash -> paused, checked before a deferred DownloadAll resumes it
+	pausedMutex    sync.RWMutex
 	downloadDir    string
+	homeDir        string
 	downloadSpeeds map[string]*speedTracker
 	uploadSpeeds   map[string]*speedTracker
 	speedsMutex    sync.RWMutex
 	depositAddress string
+
+	webSeeds          map[string][]string
+	webSeedHTTPLimits map[string]int           // infoHash -> per-torrent HTTP concurrency limit
+	webSeedSemaphores map[string]chan struct{} // infoHash -> in-flight webseed request gate
+	webSeedRegistry   []webSeedRule
+	webSeedsMutex     sync.RWMutex
+
+	defaultStorageMode    StorageMode
+	torrentStorage        map[string]StorageMode
+	storageMutex          sync.RWMutex
+	pieceCompletion       storage.PieceCompletion
+	pieceCompletionCloser io.Closer
+
+	rehydrating bool
+
+	filePriorities      map[string]map[string]string // infoHash -> file path -> priority
+	filePrioritiesMutex sync.RWMutex
+
+	downloadLimiter     *rate.Limiter
+	uploadLimiter       *rate.Limiter
+	globalRateLimits    RateLimits
+	torrentRateLimiters map[string]*torrentLimiters
+	rateLimitsMutex     sync.RWMutex
+
+	streams      map[string]*activeStream
+	streamsMutex sync.Mutex
 }
 
 // NewApp creates a new App application struct
 func NewApp() *App {
 	return &App{
-		torrents:       make(map[string]*torrent.Torrent),
-		downloadSpeeds: make(map[string]*speedTracker),
-		uploadSpeeds:   make(map[string]*speedTracker),
+		torrents:            make(map[string]*torrent.Torrent),
+		pausedTorrents:      make(map[string]bool),
+		downloadSpeeds:      make(map[string]*speedTracker),
+		uploadSpeeds:        make(map[string]*speedTracker),
+		webSeeds:            make(map[string][]string),
+		webSeedHTTPLimits:   make(map[string]int),
+		webSeedSemaphores:   make(map[string]chan struct{}),
+		defaultStorageMode:  StorageModeFile,
+		torrentStorage:      make(map[string]StorageMode),
+		filePriorities:      make(map[string]map[string]string),
+		torrentRateLimiters: make(map[string]*torrentLimiters),
+		streams:             make(map[string]*activeStream),
 	}
 }
 
@@ -101,8 +147,51 @@ func (a *App) startup(ctx context.Context) {
 		log.Printf("Error getting home directory: %v", err)
 		homeDir = "."
 	}
+	a.homeDir = homeDir
 	a.downloadDir = filepath.Join(homeDir, "TorrentFlow", "Downloads")
 
+	// Load the user-editable web-seed registry (best-effort; a missing or
+	// malformed file just means no preconfigured mirrors).
+	if rules, err := loadWebSeedRegistry(homeDir); err != nil {
+		log.Printf("Warning: failed to load web-seed registry: %v", err)
+	} else {
+		a.webSeedRegistry = rules
+	}
+
+	// Restore per-torrent storage mode choices from the previous session.
+	if choices, err := loadStorageChoices(homeDir); err != nil {
+		log.Printf("Warning: failed to load storage choices: %v", err)
+	} else {
+		a.storageMutex.Lock()
+		a.torrentStorage = choices
+		a.storageMutex.Unlock()
+	}
+
+	// Restore per-file priority selections from the previous session.
+	if priorities, err := loadFilePriorities(homeDir); err != nil {
+		log.Printf("Warning: failed to load file priorities: %v", err)
+	} else {
+		a.filePrioritiesMutex.Lock()
+		a.filePriorities = priorities
+		a.filePrioritiesMutex.Unlock()
+	}
+
+	// Restore bandwidth limits and wire the global limiters into the client
+	// config so they take effect for every connection.
+	rateSettings, err := loadRateLimitSettings(homeDir)
+	if err != nil {
+		log.Printf("Warning: failed to load rate limit settings: %v", err)
+		rateSettings = defaultRateLimitSettings()
+	}
+	a.globalRateLimits = rateSettings.Global
+	a.rateLimitsMutex.Lock()
+	for hash, limits := range rateSettings.Torrents {
+		a.torrentRateLimiters[hash] = newTorrentLimiters(limits)
+	}
+	a.rateLimitsMutex.Unlock()
+	a.downloadLimiter = rate.NewLimiter(bpsToLimit(rateSettings.Global.DownloadBps), rateLimitBurst)
+	a.uploadLimiter = rate.NewLimiter(bpsToLimit(rateSettings.Global.UploadBps), rateLimitBurst)
+
 	// Create directory if it doesn't exist
 	if err := os.MkdirAll(a.downloadDir, 0755); err != nil {
 		log.Printf("Error creating download directory: %v", err)
@@ -118,9 +207,19 @@ func (a *App) startup(ctx context.Context) {
 	cfg.DisableIPv6 = false
 	cfg.NoDHT = false      // Enable DHT for magnet links
 	cfg.ListenPort = 42069 // Set a default port
+	cfg.DownloadRateLimiter = a.downloadLimiter
+	cfg.UploadRateLimiter = a.uploadLimiter
 
-	// Use file storage
-	cfg.DefaultStorage = storage.NewFile(a.downloadDir)
+	// Open the persistent piece-completion DB so progress survives restarts,
+	// then wire up the configured default storage backend.
+	pc, pcCloser, err := openPieceCompletion(homeDir)
+	if err != nil {
+		log.Printf("Warning: failed to open piece completion DB: %v", err)
+	} else {
+		a.pieceCompletion = pc
+		a.pieceCompletionCloser = pcCloser
+	}
+	cfg.DefaultStorage = a.storageOpener()
 
 	// Create client
 	client, err := torrent.NewClient(cfg)
@@ -131,6 +230,12 @@ func (a *App) startup(ctx context.Context) {
 	}
 	a.client = client
 
+	// Rehydrate torrents from the previous session before the stats loop
+	// starts reporting, so the frontend never sees a half-restored state.
+	a.rehydrating = true
+	a.rehydrateTorrents()
+	a.rehydrating = false
+
 	// Start stats update loop
 	go a.updateStatsLoop()
 
@@ -141,19 +246,40 @@ func (a *App) startup(ctx context.Context) {
 
 // shutdown is called when the app stops
 func (a *App) shutdown(ctx context.Context) {
+	a.streamsMutex.Lock()
+	for hash := range a.streams {
+		a.stopStream(hash)
+	}
+	a.streamsMutex.Unlock()
+
 	if a.client != nil {
 		log.Println("Closing torrent client...")
 		a.client.Close()
 		log.Println("✓ Torrent client closed")
 	}
+	if a.pieceCompletionCloser != nil {
+		a.pieceCompletionCloser.Close()
+	}
 }
 
-// AddMagnet adds a torrent from a magnet link
+// AddMagnet adds a torrent from a magnet link using the default storage mode.
 func (a *App) AddMagnet(magnetURI string) error {
+	return a.AddMagnetWithOptions(magnetURI, AddOptions{Storage: a.defaultStorageMode})
+}
+
+// AddMagnetWithOptions adds a torrent from a magnet link, applying a
+// per-torrent storage backend override.
+func (a *App) AddMagnetWithOptions(magnetURI string, opts AddOptions) error {
 	if a.client == nil {
 		return fmt.Errorf("torrent client not initialized")
 	}
 
+	// Magnet URIs carry the info hash directly, so the storage mode can be
+	// registered before the client starts allocating pieces for it.
+	if m, err := metainfo.ParseMagnetURI(magnetURI); err == nil {
+		a.setTorrentStorageMode(m.InfoHash.String(), opts.storageOrDefault(a.defaultStorageMode))
+	}
+
 	t, err := a.client.AddMagnet(magnetURI)
 	if err != nil {
 		return fmt.Errorf("failed to add magnet: %w", err)
@@ -172,6 +298,16 @@ func (a *App) AddMagnet(magnetURI string) error {
 	a.torrents[hash] = t
 	a.torrentsMutex.Unlock()
 
+	if !a.rehydrating {
+		a.saveResumeEntry(resumeEntry{
+			InfoHash:  hash,
+			Source:    "magnet",
+			MagnetURI: magnetURI,
+			Storage:   string(opts.storageOrDefault(a.defaultStorageMode)),
+			AddedAt:   time.Now(),
+		})
+	}
+
 	log.Printf("Added magnet link, waiting for metadata...")
 
 	// Wait for info with timeout in background
@@ -179,7 +315,11 @@ func (a *App) AddMagnet(magnetURI string) error {
 		select {
 		case <-t.GotInfo():
 			log.Printf("✓ Got metadata for torrent: %s", t.Name())
-			t.DownloadAll()
+			a.applyRegistryWebSeeds(t)
+			if !a.isPaused(hash) {
+				t.DownloadAll()
+			}
+			a.applyFilePriorities(t)
 			wailsruntime.EventsEmit(a.ctx, "torrent-added", hash)
 		case <-time.After(60 * time.Second):
 			log.Printf("⚠ Timeout waiting for torrent metadata")
@@ -190,7 +330,15 @@ func (a *App) AddMagnet(magnetURI string) error {
 	return nil
 }
 
+// AddLocalFiles seeds a torrent built from local files using the default
+// storage mode.
 func (a *App) AddLocalFiles(paths []string) error {
+	return a.AddLocalFilesWithOptions(paths, AddOptions{Storage: a.defaultStorageMode})
+}
+
+// AddLocalFilesWithOptions seeds a torrent built from local files, applying
+// a per-torrent storage backend override.
+func (a *App) AddLocalFilesWithOptions(paths []string, opts AddOptions) error {
 	if a.client == nil {
 		return fmt.Errorf("torrent client not initialized")
 	}
@@ -199,35 +347,19 @@ func (a *App) AddLocalFiles(paths []string) error {
 		return fmt.Errorf("no files provided")
 	}
 
-	// Step 1: Build metainfo (torrent metadata)
-	info := metainfo.Info{
-		Name:        filepath.Base(paths[0]), // torrent name
-		PieceLength: 256 * 1024,              // 256 KB pieces
-	}
-
-	// If multiple files, we need to create File slices
-	var files []metainfo.FileInfo
-	for _, p := range paths {
-		fi, err := os.Stat(p)
-		if err != nil {
-			return fmt.Errorf("failed to stat file %s: %w", p, err)
-		}
-
-		files = append(files, metainfo.FileInfo{
-			Path:   []string{filepath.Base(p)},
-			Length: fi.Size(),
-		})
+	// Build real metainfo with hashed pieces so peers can verify the data
+	// we're about to seed, rather than a fabricated, unverifiable torrent.
+	mi, err := a.buildMetaInfo(paths, CreateTorrentOptions{
+		Trackers: [][]string{{"udp://tracker.openbittorrent.com:80/announce"}},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to build local files torrent: %w", err)
 	}
-	info.Files = files
 
-	// Build MetaInfo object
-	mi := &metainfo.MetaInfo{
-		AnnounceList: [][]string{
-			{"udp://tracker.openbittorrent.com:80/announce"},
-		},
-	}
+	// Register the storage choice before AddTorrent, which opens storage
+	// for the torrent as soon as it has the info dict.
+	a.setTorrentStorageMode(mi.HashInfoBytes().String(), opts.storageOrDefault(a.defaultStorageMode))
 
-	// Step 2: Add torrent to client
 	t, err := a.client.AddTorrent(mi)
 	if err != nil {
 		return fmt.Errorf("failed to add local files torrent: %w", err)
@@ -246,17 +378,37 @@ func (a *App) AddLocalFiles(paths []string) error {
 	a.torrents[hash] = t
 	a.torrentsMutex.Unlock()
 
+	a.applyRegistryWebSeeds(t)
+	a.applyFilePriorities(t)
+
 	// Start seeding
 	t.Seeding()
 
+	if !a.rehydrating {
+		a.saveResumeEntry(resumeEntry{
+			InfoHash:   hash,
+			Source:     "local",
+			LocalPaths: paths,
+			Storage:    string(opts.storageOrDefault(a.defaultStorageMode)),
+			AddedAt:    time.Now(),
+		})
+	}
+
 	log.Printf("Started seeding local files: %v", paths)
 	// runtime.EventsEmit(a.ctx, "torrent-added", hash)
 
 	return nil
 }
 
-// AddTorrentFile adds a torrent from a file
+// AddTorrentFile adds a torrent from a .torrent file using the default
+// storage mode.
 func (a *App) AddTorrentFile(filePath string) error {
+	return a.AddTorrentFileWithOptions(filePath, AddOptions{Storage: a.defaultStorageMode})
+}
+
+// AddTorrentFileWithOptions adds a torrent from a .torrent file, applying a
+// per-torrent storage backend override.
+func (a *App) AddTorrentFileWithOptions(filePath string, opts AddOptions) error {
 	if a.client == nil {
 		return fmt.Errorf("torrent client not initialized")
 	}
@@ -266,6 +418,10 @@ func (a *App) AddTorrentFile(filePath string) error {
 		return fmt.Errorf("failed to load torrent file: %w", err)
 	}
 
+	// The info hash is known up front, so register the storage choice
+	// before the client opens storage for this torrent.
+	a.setTorrentStorageMode(mi.HashInfoBytes().String(), opts.storageOrDefault(a.defaultStorageMode))
+
 	t, err := a.client.AddTorrent(mi)
 	if err != nil {
 		return fmt.Errorf("failed to add torrent: %w", err)
@@ -279,12 +435,24 @@ func (a *App) AddTorrentFile(filePath string) error {
 	a.uploadSpeeds[hash] = &speedTracker{lastTime: time.Now()}
 	a.speedsMutex.Unlock()
 
+	a.applyRegistryWebSeeds(t)
 	t.DownloadAll()
+	a.applyFilePriorities(t)
 
 	a.torrentsMutex.Lock()
 	a.torrents[hash] = t
 	a.torrentsMutex.Unlock()
 
+	if !a.rehydrating {
+		a.saveResumeEntry(resumeEntry{
+			InfoHash:    hash,
+			Source:      "torrentfile",
+			TorrentPath: filePath,
+			Storage:     string(opts.storageOrDefault(a.defaultStorageMode)),
+			AddedAt:     time.Now(),
+		})
+	}
+
 	log.Printf("✓ Added torrent file: %s", t.Name())
 	wailsruntime.EventsEmit(a.ctx, "torrent-added", hash)
 
@@ -328,7 +496,9 @@ func (a *App) PauseTorrent(infoHash string) error {
 		return fmt.Errorf("torrent not found")
 	}
 
+	a.setPaused(infoHash, true)
 	t.CancelPieces(0, t.NumPieces())
+	a.setResumePaused(infoHash, true)
 	log.Printf("⏸ Paused torrent: %s", t.Name())
 	return nil
 }
@@ -343,11 +513,29 @@ func (a *App) ResumeTorrent(infoHash string) error {
 		return fmt.Errorf("torrent not found")
 	}
 
+	a.setPaused(infoHash, false)
 	t.DownloadAll()
+	a.setResumePaused(infoHash, false)
 	log.Printf("▶ Resumed torrent: %s", t.Name())
 	return nil
 }
 
+// setPaused records whether a torrent is paused so a deferred DownloadAll
+// (e.g. the magnet goroutine waiting on GotInfo) can tell it shouldn't
+// resume a torrent that was paused out from under it.
+func (a *App) setPaused(infoHash string, paused bool) {
+	a.pausedMutex.Lock()
+	a.pausedTorrents[infoHash] = paused
+	a.pausedMutex.Unlock()
+}
+
+// isPaused reports whether a torrent is currently marked paused.
+func (a *App) isPaused(infoHash string) bool {
+	a.pausedMutex.RLock()
+	defer a.pausedMutex.RUnlock()
+	return a.pausedTorrents[infoHash]
+}
+
 // RemoveTorrent removes a torrent
 func (a *App) RemoveTorrent(infoHash string, deleteFiles bool) error {
 	a.torrentsMutex.Lock()
@@ -370,6 +558,7 @@ func (a *App) RemoveTorrent(infoHash string, deleteFiles bool) error {
 	a.speedsMutex.Unlock()
 
 	t.Drop()
+	a.deleteResumeEntry(infoHash)
 
 	if deleteFiles && t.Info() != nil {
 		// Delete files
@@ -406,6 +595,7 @@ func (a *App) GetStats() Stats {
 	}
 	a.speedsMutex.RUnlock()
 
+	var webSeedBytes int64
 	for _, t := range a.torrents {
 		stats := t.Stats()
 
@@ -414,13 +604,20 @@ func (a *App) GetStats() Stats {
 		}
 
 		totalPeers += stats.ActivePeers
+		webSeedBytes += stats.WebSeeds.BytesReadUsefulData.Int64()
 	}
 
+	a.rateLimitsMutex.RLock()
+	rateLimits := a.globalRateLimits
+	a.rateLimitsMutex.RUnlock()
+
 	return Stats{
 		TotalDownloadSpeed: formatSpeed(totalDown),
 		TotalUploadSpeed:   formatSpeed(totalUp),
 		ActiveTorrents:     activeTorrents,
 		TotalPeers:         totalPeers,
+		WebSeedBytes:       webSeedBytes,
+		RateLimits:         rateLimits,
 	}
 }
 
@@ -486,18 +683,26 @@ func (a *App) getTorrentInfo(hash string, t *torrent.Torrent) TorrentInfo {
 	// Get files info
 	var files []FileInfo
 	if t.Info() != nil {
+		priorities := a.filePrioritySnapshot(hash)
+
 		for _, file := range t.Files() {
 			fileProgress := 0.0
 			if file.Length() > 0 {
 				fileProgress = float64(file.BytesCompleted()) / float64(file.Length()) * 100
 			}
 
+			priority, ok := priorities[file.Path()]
+			if !ok {
+				priority = "normal"
+			}
+
 			files = append(files, FileInfo{
 				Name:     file.DisplayPath(),
 				Size:     file.Length(),
 				SizeStr:  formatBytes(file.Length()),
 				Progress: fileProgress,
 				Path:     file.Path(),
+				Priority: priority,
 			})
 		}
 	}
@@ -527,6 +732,22 @@ func (a *App) getTorrentInfo(hash string, t *torrent.Torrent) TorrentInfo {
 		name = "Loading metadata..."
 	}
 
+	a.webSeedsMutex.RLock()
+	webSeeds := append([]string(nil), a.webSeeds[hash]...)
+	a.webSeedsMutex.RUnlock()
+
+	a.storageMutex.RLock()
+	storageMode, ok := a.torrentStorage[hash]
+	a.storageMutex.RUnlock()
+	if !ok {
+		storageMode = a.defaultStorageMode
+	}
+
+	a.rateLimitsMutex.RLock()
+	_, throttled := a.torrentRateLimiters[hash]
+	globalThrottled := a.globalRateLimits.DownloadBps > 0 || a.globalRateLimits.UploadBps > 0
+	a.rateLimitsMutex.RUnlock()
+
 	return TorrentInfo{
 		ID:            hash,
 		Name:          name,
@@ -544,6 +765,9 @@ func (a *App) getTorrentInfo(hash string, t *torrent.Torrent) TorrentInfo {
 		ETA:           eta,
 		Files:         files,
 		AddedAt:       time.Now(),
+		WebSeeds:      webSeeds,
+		Storage:       string(storageMode),
+		Throttled:     throttled || globalThrottled,
 	}
 }
 
@@ -668,20 +892,6 @@ func (a *App) SelectLocalFiles() ([]string, error) {
 	return files, nil
 }
 
-// CreateTorrentFromFiles creates a torrent from selected local files
-func (a *App) CreateTorrentFromFiles(files []string) error {
-	if len(files) == 0 {
-		return fmt.Errorf("no files provided")
-	}
-
-	// TODO: Implement actual torrent creation logic if needed
-	// For now, we just log the files
-	log.Printf("Creating torrent from files: %v", files)
-	wailsruntime.LogInfo(a.ctx, fmt.Sprintf("Creating torrent from files: %v", files))
-
-	return nil
-}
-
 // GetBalance returns the user balance (mocked for now)
 func (a *App) GetBalance() (float64, error) {
 	// TODO: Replace with actual balance fetching logic if needed
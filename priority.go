@@ -0,0 +1,208 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/anacrolix/torrent"
+)
+
+func filePrioritiesPath(homeDir string) string {
+	return filepath.Join(homeDir, "TorrentFlow", "state", "priorities.json")
+}
+
+func loadFilePriorities(homeDir string) (map[string]map[string]string, error) {
+	data, err := os.ReadFile(filePrioritiesPath(homeDir))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return make(map[string]map[string]string), nil
+		}
+		return nil, fmt.Errorf("failed to read file priorities: %w", err)
+	}
+
+	priorities := make(map[string]map[string]string)
+	if err := json.Unmarshal(data, &priorities); err != nil {
+		return nil, fmt.Errorf("failed to parse file priorities: %w", err)
+	}
+	return priorities, nil
+}
+
+func saveFilePriorities(homeDir string, priorities map[string]map[string]string) error {
+	path := filePrioritiesPath(homeDir)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create state directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(priorities, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode file priorities: %w", err)
+	}
+
+	return os.WriteFile(path, data, 0644)
+}
+
+// piecePriorityFor translates the frontend's priority string into the
+// torrent library's piece priority. The library has no level between None
+// and Normal, so "low" and "normal" both map to Normal.
+func piecePriorityFor(priority string) (torrent.PiecePriority, error) {
+	switch priority {
+	case "skip":
+		return torrent.PiecePriorityNone, nil
+	case "low", "normal":
+		return torrent.PiecePriorityNormal, nil
+	case "high":
+		return torrent.PiecePriorityHigh, nil
+	default:
+		return 0, fmt.Errorf("unknown priority: %s", priority)
+	}
+}
+
+// SetFilePriority changes how eagerly a single file within a torrent is
+// downloaded. priority is one of "skip", "low", "normal", or "high".
+func (a *App) SetFilePriority(infoHash, filePath, priority string) error {
+	a.torrentsMutex.RLock()
+	t, exists := a.torrents[infoHash]
+	a.torrentsMutex.RUnlock()
+
+	if !exists {
+		return fmt.Errorf("torrent not found")
+	}
+
+	piecePriority, err := piecePriorityFor(priority)
+	if err != nil {
+		return err
+	}
+
+	file, err := findFile(t, filePath)
+	if err != nil {
+		return err
+	}
+
+	file.SetPriority(piecePriority)
+	if priority == "skip" {
+		t.CancelPieces(file.BeginPieceIndex(), file.EndPieceIndex())
+	}
+
+	a.rememberFilePriority(infoHash, filePath, priority)
+
+	log.Printf("Set priority %q for %s in torrent: %s", priority, filePath, t.Name())
+	return nil
+}
+
+// SetFilesToDownload restricts a torrent to only the given files, skipping
+// everything else.
+func (a *App) SetFilesToDownload(infoHash string, paths []string) error {
+	a.torrentsMutex.RLock()
+	t, exists := a.torrents[infoHash]
+	a.torrentsMutex.RUnlock()
+
+	if !exists {
+		return fmt.Errorf("torrent not found")
+	}
+
+	wanted := make(map[string]bool, len(paths))
+	for _, p := range paths {
+		wanted[p] = true
+	}
+
+	for _, file := range t.Files() {
+		priority := "skip"
+		if wanted[file.Path()] {
+			priority = "normal"
+		}
+
+		piecePriority, err := piecePriorityFor(priority)
+		if err != nil {
+			return err
+		}
+
+		file.SetPriority(piecePriority)
+		if priority == "skip" {
+			t.CancelPieces(file.BeginPieceIndex(), file.EndPieceIndex())
+		}
+
+		a.rememberFilePriority(infoHash, file.Path(), priority)
+	}
+
+	log.Printf("Selected %d file(s) to download for torrent: %s", len(paths), t.Name())
+	return nil
+}
+
+// applyFilePriorities re-applies previously saved per-file priorities to a
+// torrent once its file list is known, e.g. after a resume-store rehydration
+// or once a magnet link's metadata arrives.
+func (a *App) applyFilePriorities(t *torrent.Torrent) {
+	priorities := a.filePrioritySnapshot(t.InfoHash().String())
+
+	if len(priorities) == 0 {
+		return
+	}
+
+	for _, file := range t.Files() {
+		priority, ok := priorities[file.Path()]
+		if !ok {
+			continue
+		}
+
+		piecePriority, err := piecePriorityFor(priority)
+		if err != nil {
+			continue
+		}
+
+		file.SetPriority(piecePriority)
+		if priority == "skip" {
+			t.CancelPieces(file.BeginPieceIndex(), file.EndPieceIndex())
+		}
+	}
+}
+
+func (a *App) rememberFilePriority(infoHash, filePath, priority string) {
+	a.filePrioritiesMutex.Lock()
+	if a.filePriorities[infoHash] == nil {
+		a.filePriorities[infoHash] = make(map[string]string)
+	}
+	a.filePriorities[infoHash][filePath] = priority
+	snapshot := make(map[string]map[string]string, len(a.filePriorities))
+	for hash, files := range a.filePriorities {
+		filesCopy := make(map[string]string, len(files))
+		for path, p := range files {
+			filesCopy[path] = p
+		}
+		snapshot[hash] = filesCopy
+	}
+	a.filePrioritiesMutex.Unlock()
+
+	if err := saveFilePriorities(a.homeDir, snapshot); err != nil {
+		log.Printf("Warning: failed to persist file priorities: %v", err)
+	}
+}
+
+// filePrioritySnapshot returns a deep copy of the per-file priorities
+// recorded for a torrent, safe to read after the lock is released.
+func (a *App) filePrioritySnapshot(infoHash string) map[string]string {
+	a.filePrioritiesMutex.RLock()
+	defer a.filePrioritiesMutex.RUnlock()
+
+	files := a.filePriorities[infoHash]
+	if len(files) == 0 {
+		return nil
+	}
+
+	snapshot := make(map[string]string, len(files))
+	for path, p := range files {
+		snapshot[path] = p
+	}
+	return snapshot
+}
+
+func findFile(t *torrent.Torrent, filePath string) (*torrent.File, error) {
+	for _, file := range t.Files() {
+		if file.Path() == filePath {
+			return file, nil
+		}
+	}
+	return nil, fmt.Errorf("file not found in torrent: %s", filePath)
+}
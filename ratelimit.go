@@ -0,0 +1,260 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/anacrolix/torrent/metainfo"
+	"github.com/anacrolix/torrent/storage"
+	"golang.org/x/time/rate"
+)
+
+// rateLimitBurst bounds how much a limiter lets through in one go. Reads and
+// writes are throttled in rateLimitChunk-sized steps (see waitForBudget), so
+// this only needs to cover one chunk.
+const (
+	rateLimitBurst = 64 * 1024
+	rateLimitChunk = 32 * 1024
+)
+
+// RateLimits caps download/upload throughput in bytes per second. A value of
+// 0 means unlimited.
+type RateLimits struct {
+	DownloadBps int64 `json:"downloadBps"`
+	UploadBps   int64 `json:"uploadBps"`
+}
+
+// torrentLimiters holds the token buckets gating one torrent's piece I/O.
+type torrentLimiters struct {
+	limits   RateLimits
+	download *rate.Limiter
+	upload   *rate.Limiter
+}
+
+func newTorrentLimiters(limits RateLimits) *torrentLimiters {
+	return &torrentLimiters{
+		limits:   limits,
+		download: rate.NewLimiter(bpsToLimit(limits.DownloadBps), rateLimitBurst),
+		upload:   rate.NewLimiter(bpsToLimit(limits.UploadBps), rateLimitBurst),
+	}
+}
+
+func bpsToLimit(bps int64) rate.Limit {
+	if bps <= 0 {
+		return rate.Inf
+	}
+	return rate.Limit(bps)
+}
+
+type rateLimitSettings struct {
+	Global   RateLimits            `json:"global"`
+	Torrents map[string]RateLimits `json:"torrents"`
+}
+
+func defaultRateLimitSettings() rateLimitSettings {
+	return rateLimitSettings{Torrents: make(map[string]RateLimits)}
+}
+
+func rateLimitSettingsPath(homeDir string) string {
+	return filepath.Join(homeDir, "TorrentFlow", "state", "ratelimits.json")
+}
+
+func loadRateLimitSettings(homeDir string) (rateLimitSettings, error) {
+	data, err := os.ReadFile(rateLimitSettingsPath(homeDir))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return defaultRateLimitSettings(), nil
+		}
+		return rateLimitSettings{}, fmt.Errorf("failed to read rate limit settings: %w", err)
+	}
+
+	settings := defaultRateLimitSettings()
+	if err := json.Unmarshal(data, &settings); err != nil {
+		return rateLimitSettings{}, fmt.Errorf("failed to parse rate limit settings: %w", err)
+	}
+	if settings.Torrents == nil {
+		settings.Torrents = make(map[string]RateLimits)
+	}
+	return settings, nil
+}
+
+func saveRateLimitSettings(homeDir string, settings rateLimitSettings) error {
+	path := rateLimitSettingsPath(homeDir)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create state directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(settings, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode rate limit settings: %w", err)
+	}
+
+	return os.WriteFile(path, data, 0644)
+}
+
+// SetGlobalRateLimits caps overall download/upload throughput across every
+// torrent. A value of 0 means unlimited; negative values are rejected.
+func (a *App) SetGlobalRateLimits(downBps, upBps int64) error {
+	if downBps < 0 || upBps < 0 {
+		return fmt.Errorf("rate limit cannot be negative")
+	}
+
+	a.rateLimitsMutex.Lock()
+	a.globalRateLimits = RateLimits{DownloadBps: downBps, UploadBps: upBps}
+	if a.downloadLimiter != nil {
+		a.downloadLimiter.SetLimit(bpsToLimit(downBps))
+	}
+	if a.uploadLimiter != nil {
+		a.uploadLimiter.SetLimit(bpsToLimit(upBps))
+	}
+	torrents := make(map[string]RateLimits, len(a.torrentRateLimiters))
+	for hash, l := range a.torrentRateLimiters {
+		torrents[hash] = l.limits
+	}
+	a.rateLimitsMutex.Unlock()
+
+	if err := a.persistRateLimitSettings(torrents); err != nil {
+		log.Printf("Warning: failed to persist rate limit settings: %v", err)
+	}
+
+	log.Printf("Set global rate limits: down=%d B/s up=%d B/s", downBps, upBps)
+	return nil
+}
+
+// SetTorrentRateLimits caps a single torrent's throughput, independent of
+// (and in addition to) any global cap.
+func (a *App) SetTorrentRateLimits(infoHash string, downBps, upBps int64) error {
+	if downBps < 0 || upBps < 0 {
+		return fmt.Errorf("rate limit cannot be negative")
+	}
+
+	a.torrentsMutex.RLock()
+	t, exists := a.torrents[infoHash]
+	a.torrentsMutex.RUnlock()
+
+	if !exists {
+		return fmt.Errorf("torrent not found")
+	}
+
+	limits := RateLimits{DownloadBps: downBps, UploadBps: upBps}
+
+	a.rateLimitsMutex.Lock()
+	if downBps == 0 && upBps == 0 {
+		delete(a.torrentRateLimiters, infoHash)
+	} else {
+		a.torrentRateLimiters[infoHash] = newTorrentLimiters(limits)
+	}
+	torrents := make(map[string]RateLimits, len(a.torrentRateLimiters))
+	for hash, l := range a.torrentRateLimiters {
+		torrents[hash] = l.limits
+	}
+	a.rateLimitsMutex.Unlock()
+
+	t.SetMaxEstablishedConns(connsForRateLimit(downBps, upBps))
+
+	if err := a.persistRateLimitSettings(torrents); err != nil {
+		log.Printf("Warning: failed to persist rate limit settings: %v", err)
+	}
+
+	log.Printf("Set rate limits for torrent %s: down=%d B/s up=%d B/s", t.Name(), downBps, upBps)
+	return nil
+}
+
+func (a *App) persistRateLimitSettings(torrents map[string]RateLimits) error {
+	a.rateLimitsMutex.RLock()
+	global := a.globalRateLimits
+	a.rateLimitsMutex.RUnlock()
+
+	return saveRateLimitSettings(a.homeDir, rateLimitSettings{Global: global, Torrents: torrents})
+}
+
+// connsForRateLimit scales down the number of established peer connections
+// a heavily-throttled torrent is allowed, since a slow cap spread across
+// many peers just wastes connection overhead. Unlimited keeps the library
+// default of 50.
+func connsForRateLimit(downBps, upBps int64) int {
+	const defaultConns = 50
+
+	bps := downBps
+	if upBps > 0 && (bps == 0 || upBps < bps) {
+		bps = upBps
+	}
+	if bps <= 0 {
+		return defaultConns
+	}
+
+	conns := int(bps / (64 * 1024))
+	if conns < 4 {
+		conns = 4
+	}
+	if conns > defaultConns {
+		conns = defaultConns
+	}
+	return conns
+}
+
+// wrapWithRateLimiting gates a torrent's piece reads (serving data to peers,
+// i.e. upload) and writes (receiving downloaded data) through that
+// torrent's token buckets, if any are configured.
+func wrapWithRateLimiting(app *App, infoHash string, ti storage.TorrentImpl) storage.TorrentImpl {
+	original := ti.Piece
+	ti.Piece = func(p metainfo.Piece) storage.PieceImpl {
+		base := original(p)
+
+		app.rateLimitsMutex.RLock()
+		limiters, ok := app.torrentRateLimiters[infoHash]
+		app.rateLimitsMutex.RUnlock()
+		if !ok {
+			return base
+		}
+
+		return &throttledPiece{PieceImpl: base, limiters: limiters}
+	}
+	return ti
+}
+
+// throttledPiece wraps a storage.PieceImpl so reads and writes drain the
+// torrent's rate limiters before being let through.
+type throttledPiece struct {
+	storage.PieceImpl
+	limiters *torrentLimiters
+}
+
+func (p *throttledPiece) ReadAt(b []byte, off int64) (int, error) {
+	n, err := p.PieceImpl.ReadAt(b, off)
+	if n > 0 {
+		waitForBudget(p.limiters.upload, n)
+	}
+	return n, err
+}
+
+func (p *throttledPiece) WriteAt(b []byte, off int64) (int, error) {
+	waitForBudget(p.limiters.download, len(b))
+	return p.PieceImpl.WriteAt(b, off)
+}
+
+// waitForBudget drains n bytes worth of tokens from limiter in
+// rateLimitChunk-sized reservations, so a single large piece I/O never
+// requests more burst than the limiter was built with.
+func waitForBudget(limiter *rate.Limiter, n int) {
+	if limiter.Limit() == rate.Inf {
+		return
+	}
+
+	for n > 0 {
+		take := n
+		if take > rateLimitChunk {
+			take = rateLimitChunk
+		}
+		reservation := limiter.ReserveN(time.Now(), take)
+		if !reservation.OK() {
+			return
+		}
+		time.Sleep(reservation.Delay())
+		n -= take
+	}
+}
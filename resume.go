@@ -0,0 +1,179 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	wailsruntime "github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// resumeEntry captures enough information to re-add a torrent exactly as it
+// was added, so restarting TorrentFlow doesn't forget anything in progress.
+type resumeEntry struct {
+	InfoHash    string    `json:"infoHash"`
+	Source      string    `json:"source"` // "magnet", "torrentfile", or "local"
+	MagnetURI   string    `json:"magnetUri,omitempty"`
+	TorrentPath string    `json:"torrentPath,omitempty"`
+	LocalPaths  []string  `json:"localPaths,omitempty"`
+	Storage     string    `json:"storage,omitempty"`
+	Paused      bool      `json:"paused"`
+	AddedAt     time.Time `json:"addedAt"`
+}
+
+func resumeStorePath(homeDir string) string {
+	return filepath.Join(homeDir, "TorrentFlow", "state", "resume.json")
+}
+
+func loadResumeEntries(homeDir string) (map[string]resumeEntry, error) {
+	data, err := os.ReadFile(resumeStorePath(homeDir))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return make(map[string]resumeEntry), nil
+		}
+		return nil, fmt.Errorf("failed to read resume store: %w", err)
+	}
+
+	entries := make(map[string]resumeEntry)
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("failed to parse resume store: %w", err)
+	}
+	return entries, nil
+}
+
+func saveResumeEntries(homeDir string, entries map[string]resumeEntry) error {
+	path := resumeStorePath(homeDir)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create state directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode resume store: %w", err)
+	}
+
+	return os.WriteFile(path, data, 0644)
+}
+
+// saveResumeEntry upserts a single torrent's resume record.
+func (a *App) saveResumeEntry(entry resumeEntry) {
+	entries, err := loadResumeEntries(a.homeDir)
+	if err != nil {
+		log.Printf("Warning: failed to load resume store: %v", err)
+		entries = make(map[string]resumeEntry)
+	}
+
+	entries[entry.InfoHash] = entry
+
+	if err := saveResumeEntries(a.homeDir, entries); err != nil {
+		log.Printf("Warning: failed to persist resume entry: %v", err)
+	}
+}
+
+// deleteResumeEntry drops a torrent's resume record, e.g. on removal.
+func (a *App) deleteResumeEntry(infoHash string) {
+	entries, err := loadResumeEntries(a.homeDir)
+	if err != nil {
+		log.Printf("Warning: failed to load resume store: %v", err)
+		return
+	}
+
+	delete(entries, infoHash)
+
+	if err := saveResumeEntries(a.homeDir, entries); err != nil {
+		log.Printf("Warning: failed to persist resume store: %v", err)
+	}
+}
+
+// setResumePaused updates the paused flag of an existing resume record.
+func (a *App) setResumePaused(infoHash string, paused bool) {
+	entries, err := loadResumeEntries(a.homeDir)
+	if err != nil {
+		return
+	}
+
+	entry, ok := entries[infoHash]
+	if !ok {
+		return
+	}
+	entry.Paused = paused
+	entries[infoHash] = entry
+
+	if err := saveResumeEntries(a.homeDir, entries); err != nil {
+		log.Printf("Warning: failed to persist resume state: %v", err)
+	}
+}
+
+// rehydrateTorrents re-adds every torrent recorded in the resume store from
+// a previous session. Piece-completion state is restored by the storage
+// backend itself (see storage.go), so downloads continue rather than
+// restart from zero.
+func (a *App) rehydrateTorrents() {
+	entries, err := loadResumeEntries(a.homeDir)
+	if err != nil {
+		log.Printf("Warning: failed to load resume store: %v", err)
+		return
+	}
+
+	if len(entries) == 0 {
+		return
+	}
+
+	log.Printf("Rehydrating %d torrent(s) from previous session...", len(entries))
+
+	i := 0
+	for hash, entry := range entries {
+		i++
+		wailsruntime.EventsEmit(a.ctx, "resume-progress", map[string]interface{}{
+			"index": i,
+			"total": len(entries),
+			"hash":  hash,
+		})
+
+		opts := AddOptions{Storage: StorageMode(entry.Storage)}
+
+		// Mark the torrent paused before adding it so a magnet's background
+		// goroutine sees the flag no matter how quickly metadata arrives,
+		// rather than racing the PauseTorrent call below.
+		if entry.Paused {
+			a.setPaused(hash, true)
+		}
+
+		var addErr error
+		switch entry.Source {
+		case "magnet":
+			addErr = a.AddMagnetWithOptions(entry.MagnetURI, opts)
+		case "torrentfile":
+			addErr = a.AddTorrentFileWithOptions(entry.TorrentPath, opts)
+		case "local":
+			addErr = a.AddLocalFilesWithOptions(entry.LocalPaths, opts)
+		default:
+			addErr = fmt.Errorf("unknown resume source %q", entry.Source)
+		}
+
+		if addErr != nil {
+			log.Printf("Warning: failed to rehydrate torrent %s: %v", hash, addErr)
+			continue
+		}
+
+		if entry.Paused {
+			if err := a.PauseTorrent(hash); err != nil {
+				log.Printf("Warning: failed to restore paused state for %s: %v", hash, err)
+			}
+		}
+
+		a.rateLimitsMutex.RLock()
+		limiters, hasLimits := a.torrentRateLimiters[hash]
+		a.rateLimitsMutex.RUnlock()
+		if hasLimits {
+			if err := a.SetTorrentRateLimits(hash, limiters.limits.DownloadBps, limiters.limits.UploadBps); err != nil {
+				log.Printf("Warning: failed to restore rate limits for %s: %v", hash, err)
+			}
+		}
+	}
+
+	log.Printf("✓ Rehydration complete")
+}
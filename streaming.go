@@ -0,0 +1,183 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/anacrolix/torrent"
+)
+
+// streamReadaheadPieces marks this many pieces at the start and end of a
+// streamed file as PiecePriorityNow so container headers and any trailing
+// index (e.g. an MP4 moov atom) arrive quickly, before the sequential read
+// catches up to them naturally.
+const streamReadaheadPieces = 4
+
+// streamReadahead is how far ahead of the current read position the
+// torrent.Reader is allowed to buffer. Fixed generously for typical media
+// bitrates rather than measured live.
+const streamReadahead = 4 * 1024 * 1024
+
+// activeStream tracks the resources backing one EnableStreaming call so
+// DisableStreaming can tear them down cleanly.
+type activeStream struct {
+	server   *http.Server
+	listener net.Listener
+	file     *torrent.File
+	reader   torrent.Reader
+}
+
+// EnableStreaming starts a local HTTP server that serves a torrent's file
+// with Range support, suitable for handing to a <video>/<audio> element
+// before the whole file has downloaded. Only one stream per torrent is
+// supported at a time.
+func (a *App) EnableStreaming(infoHash, filePath string) (string, error) {
+	a.torrentsMutex.RLock()
+	t, exists := a.torrents[infoHash]
+	a.torrentsMutex.RUnlock()
+
+	if !exists {
+		return "", fmt.Errorf("torrent not found")
+	}
+
+	file, err := findFile(t, filePath)
+	if err != nil {
+		return "", err
+	}
+
+	a.streamsMutex.Lock()
+	if _, exists := a.streams[infoHash]; exists {
+		a.streamsMutex.Unlock()
+		return "", fmt.Errorf("streaming already active for torrent")
+	}
+	// Reserve the slot before releasing the lock so a concurrent
+	// EnableStreaming call for the same torrent is rejected immediately,
+	// instead of racing us to open its own listener/reader.
+	a.streams[infoHash] = &activeStream{}
+	a.streamsMutex.Unlock()
+
+	stream, err := a.startStream(t, file)
+	if err != nil {
+		a.streamsMutex.Lock()
+		delete(a.streams, infoHash)
+		a.streamsMutex.Unlock()
+		return "", err
+	}
+
+	a.streamsMutex.Lock()
+	a.streams[infoHash] = stream
+	a.streamsMutex.Unlock()
+
+	url := fmt.Sprintf("http://%s/", stream.listener.Addr().String())
+	log.Printf("▶ Streaming %s at %s", file.DisplayPath(), url)
+	return url, nil
+}
+
+// startStream opens the reader and listener backing a stream. It must be
+// called without streamsMutex held, since NewReader/net.Listen can block.
+func (a *App) startStream(t *torrent.Torrent, file *torrent.File) (*activeStream, error) {
+	file.SetPriority(torrent.PiecePriorityNormal)
+	prioritizeStreamEdges(t, file)
+
+	reader := file.NewReader()
+	reader.SetReadahead(streamReadahead)
+	reader.SetResponsive()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		reader.Close()
+		return nil, fmt.Errorf("failed to open stream listener: %w", err)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		http.ServeContent(w, r, file.DisplayPath(), time.Time{}, reader)
+	})
+	server := &http.Server{Handler: mux}
+
+	go func() {
+		if err := server.Serve(listener); err != nil && err != http.ErrServerClosed {
+			log.Printf("Warning: stream server for %s stopped: %v", t.Name(), err)
+		}
+	}()
+
+	return &activeStream{
+		server:   server,
+		listener: listener,
+		file:     file,
+		reader:   reader,
+	}, nil
+}
+
+// DisableStreaming shuts down a torrent's stream server and restores
+// default (sequential-agnostic) piece priorities.
+func (a *App) DisableStreaming(infoHash string) error {
+	a.streamsMutex.Lock()
+	stream, exists := a.streams[infoHash]
+	if exists && stream.server != nil {
+		delete(a.streams, infoHash)
+	}
+	a.streamsMutex.Unlock()
+
+	if !exists || stream.server == nil {
+		return fmt.Errorf("no active stream for torrent")
+	}
+
+	a.closeStream(stream)
+
+	log.Printf("⏹ Stopped streaming for torrent: %s", infoHash)
+	return nil
+}
+
+// stopStream is DisableStreaming's internal counterpart, used when the
+// caller already holds a.streamsMutex (e.g. during shutdown).
+func (a *App) stopStream(infoHash string) {
+	stream, exists := a.streams[infoHash]
+	// A nil server means EnableStreaming has reserved the slot but hasn't
+	// finished opening its resources yet; nothing to close.
+	if !exists || stream.server == nil {
+		return
+	}
+	delete(a.streams, infoHash)
+	a.closeStream(stream)
+}
+
+func (a *App) closeStream(stream *activeStream) {
+	stream.server.Close()
+	stream.reader.Close()
+	stream.file.SetPriority(torrent.PiecePriorityNormal)
+	resetStreamEdges(stream.file.Torrent(), stream.file)
+}
+
+// prioritizeStreamEdges bumps the first and last few pieces of a file to
+// PiecePriorityNow so playback can start immediately and seeking to the end
+// (e.g. for a moov atom) doesn't stall.
+func prioritizeStreamEdges(t *torrent.Torrent, file *torrent.File) {
+	begin := file.BeginPieceIndex()
+	end := file.EndPieceIndex()
+
+	for i := begin; i < end && i < begin+streamReadaheadPieces; i++ {
+		t.Piece(i).SetPriority(torrent.PiecePriorityNow)
+	}
+	for i := end - streamReadaheadPieces; i < end; i++ {
+		if i >= begin {
+			t.Piece(i).SetPriority(torrent.PiecePriorityNow)
+		}
+	}
+}
+
+// resetStreamEdges clears the piece-level priority set by
+// prioritizeStreamEdges, so those pieces follow the file's priority again.
+func resetStreamEdges(t *torrent.Torrent, file *torrent.File) {
+	begin := file.BeginPieceIndex()
+	end := file.EndPieceIndex()
+
+	for i := begin; i < end; i++ {
+		if i < begin+streamReadaheadPieces || i >= end-streamReadaheadPieces {
+			t.Piece(i).SetPriority(torrent.PiecePriorityNone)
+		}
+	}
+}
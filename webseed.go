@@ -0,0 +1,266 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/anacrolix/torrent"
+	"github.com/anacrolix/torrent/webseed"
+)
+
+// defaultWebSeedHTTPLimit is the concurrency cap used for a torrent that
+// hasn't had an explicit limit set via SetWebSeedHTTPLimit.
+const defaultWebSeedHTTPLimit = 10
+
+// webSeedRule maps a torrent-name glob or info-hash prefix to a list of
+// HTTP(S) mirror URLs. Rules are read from the user-editable registry at
+// ~/TorrentFlow/webseeds.json, e.g.:
+//
+//	[
+//	  {"match": "*.iso", "urls": ["https://mirror.example.com/isos/"]},
+//	  {"match": "1a2b3c", "urls": ["https://example.com/webseed/"]}
+//	]
+type webSeedRule struct {
+	Match string   `json:"match"`
+	URLs  []string `json:"urls"`
+}
+
+func webSeedRegistryPath(homeDir string) string {
+	return filepath.Join(homeDir, "TorrentFlow", "webseeds.json")
+}
+
+// loadWebSeedRegistry reads the user registry file. A missing file is not an
+// error; it just means there are no preconfigured mirrors.
+func loadWebSeedRegistry(homeDir string) ([]webSeedRule, error) {
+	data, err := os.ReadFile(webSeedRegistryPath(homeDir))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read web-seed registry: %w", err)
+	}
+
+	var rules []webSeedRule
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return nil, fmt.Errorf("failed to parse web-seed registry: %w", err)
+	}
+	return rules, nil
+}
+
+// matches reports whether a rule applies to the given torrent, either by
+// info-hash prefix or by a glob against the torrent name.
+func (r webSeedRule) matches(hash, name string) bool {
+	if strings.HasPrefix(hash, strings.ToLower(r.Match)) {
+		return true
+	}
+	ok, err := filepath.Match(r.Match, name)
+	return err == nil && ok
+}
+
+// applyRegistryWebSeeds merges any registry URLs matching this torrent into
+// its active web seeds. Called once metadata is available.
+func (a *App) applyRegistryWebSeeds(t *torrent.Torrent) {
+	hash := t.InfoHash().String()
+	name := t.Name()
+
+	a.webSeedsMutex.RLock()
+	rules := a.webSeedRegistry
+	a.webSeedsMutex.RUnlock()
+
+	var urls []string
+	for _, rule := range rules {
+		if rule.matches(hash, name) {
+			urls = append(urls, rule.URLs...)
+		}
+	}
+
+	if len(urls) == 0 {
+		return
+	}
+
+	if err := a.AddWebSeeds(hash, urls); err != nil {
+		log.Printf("Warning: failed to apply registry web seeds for %s: %v", name, err)
+	}
+}
+
+// AddWebSeeds registers additional HTTP(S) mirror URLs (BEP 19) for a
+// torrent so peers can be supplemented with direct HTTP fetches.
+func (a *App) AddWebSeeds(infoHash string, urls []string) error {
+	a.torrentsMutex.RLock()
+	t, exists := a.torrents[infoHash]
+	a.torrentsMutex.RUnlock()
+
+	if !exists {
+		return fmt.Errorf("torrent not found")
+	}
+
+	if len(urls) == 0 {
+		return fmt.Errorf("no web seed URLs provided")
+	}
+
+	a.webSeedsMutex.Lock()
+	existing := a.webSeeds[infoHash]
+	for _, u := range urls {
+		if !containsString(existing, u) {
+			existing = append(existing, u)
+		}
+	}
+	a.webSeeds[infoHash] = existing
+	a.webSeedsMutex.Unlock()
+
+	t.AddWebSeeds(urls, a.webSeedClientOpts(infoHash)...)
+
+	log.Printf("✓ Added %d web seed(s) to torrent: %s", len(urls), t.Name())
+	return nil
+}
+
+// RemoveWebSeeds drops previously added mirror URLs from a torrent's tracked
+// web-seed list. The underlying torrent library has no API to detach an
+// individual web seed peer once added, so this only affects what TorrentFlow
+// reports. The tracked list is kept in memory only and is not persisted.
+func (a *App) RemoveWebSeeds(infoHash string, urls []string) error {
+	a.webSeedsMutex.Lock()
+	defer a.webSeedsMutex.Unlock()
+
+	existing, exists := a.webSeeds[infoHash]
+	if !exists {
+		return fmt.Errorf("torrent not found")
+	}
+
+	remaining := existing[:0]
+	for _, u := range existing {
+		if !containsString(urls, u) {
+			remaining = append(remaining, u)
+		}
+	}
+	a.webSeeds[infoHash] = remaining
+
+	return nil
+}
+
+// SetGlobalWebSeeds applies mirror URLs to every currently-known torrent.
+// Intended for a user who wants a single personal mirror applied broadly.
+func (a *App) SetGlobalWebSeeds(urls []string) error {
+	a.torrentsMutex.RLock()
+	hashes := make([]string, 0, len(a.torrents))
+	for hash := range a.torrents {
+		hashes = append(hashes, hash)
+	}
+	a.torrentsMutex.RUnlock()
+
+	for _, hash := range hashes {
+		if err := a.AddWebSeeds(hash, urls); err != nil {
+			log.Printf("Warning: failed to set global web seeds for %s: %v", hash, err)
+		}
+	}
+
+	return nil
+}
+
+// webSeedSemaphore returns the channel-based semaphore gating concurrent
+// webseed HTTP requests for a torrent, creating one sized to the configured
+// (or default) limit the first time it's needed.
+func (a *App) webSeedSemaphore(infoHash string) chan struct{} {
+	a.webSeedsMutex.Lock()
+	defer a.webSeedsMutex.Unlock()
+
+	if sem, ok := a.webSeedSemaphores[infoHash]; ok {
+		return sem
+	}
+
+	limit := a.webSeedHTTPLimits[infoHash]
+	if limit <= 0 {
+		limit = defaultWebSeedHTTPLimit
+	}
+	sem := make(chan struct{}, limit)
+	a.webSeedSemaphores[infoHash] = sem
+	return sem
+}
+
+// webSeedLimitedTransport wraps an http.RoundTripper so that no more than
+// the torrent's configured number of webseed requests are in flight at
+// once. It looks the semaphore up on every round trip rather than caching
+// it, so a limit change via SetWebSeedHTTPLimit takes effect immediately.
+//
+// torrent.WebSeedTorrentMaxRequests looks like the built-in way to do this,
+// but its doc comment says concurrency limiting "is done Client-wide" now
+// and the webseed.Client.MaxRequests field it sets is marked unused, so it
+// silently limits nothing.
+type webSeedLimitedTransport struct {
+	base     http.RoundTripper
+	app      *App
+	infoHash string
+}
+
+func (t *webSeedLimitedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	sem := t.app.webSeedSemaphore(t.infoHash)
+
+	select {
+	case sem <- struct{}{}:
+	case <-req.Context().Done():
+		return nil, req.Context().Err()
+	}
+	defer func() { <-sem }()
+
+	return t.base.RoundTrip(req)
+}
+
+// webSeedClientOpts bounds a torrent's HTTP concurrency against web seeds so
+// a slow or misbehaving mirror can't monopolize connections.
+func (a *App) webSeedClientOpts(infoHash string) []torrent.AddWebSeedsOpt {
+	return []torrent.AddWebSeedsOpt{
+		func(c *webseed.Client) {
+			base := http.RoundTripper(http.DefaultTransport)
+			httpClient := &http.Client{}
+			if c.HttpClient != nil {
+				if c.HttpClient.Transport != nil {
+					base = c.HttpClient.Transport
+				}
+				httpClient.Timeout = c.HttpClient.Timeout
+				httpClient.Jar = c.HttpClient.Jar
+				httpClient.CheckRedirect = c.HttpClient.CheckRedirect
+			}
+			httpClient.Transport = &webSeedLimitedTransport{base: base, app: a, infoHash: infoHash}
+			c.HttpClient = httpClient
+		},
+	}
+}
+
+// SetWebSeedHTTPLimit sets the maximum number of concurrent HTTP requests a
+// torrent may have in flight against its web seeds. Takes effect
+// immediately, including for webseed connections already added.
+func (a *App) SetWebSeedHTTPLimit(infoHash string, limit int) error {
+	if limit <= 0 {
+		return fmt.Errorf("limit must be positive")
+	}
+
+	a.torrentsMutex.RLock()
+	t, exists := a.torrents[infoHash]
+	a.torrentsMutex.RUnlock()
+
+	if !exists {
+		return fmt.Errorf("torrent not found")
+	}
+
+	a.webSeedsMutex.Lock()
+	a.webSeedHTTPLimits[infoHash] = limit
+	a.webSeedSemaphores[infoHash] = make(chan struct{}, limit)
+	a.webSeedsMutex.Unlock()
+
+	log.Printf("Set web seed HTTP limit to %d for torrent: %s", limit, t.Name())
+	return nil
+}
+
+func containsString(list []string, v string) bool {
+	for _, s := range list {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}